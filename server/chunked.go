@@ -0,0 +1,146 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxChunkSize bounds the size any single chunk may declare, guarding the
+// chunk-size parser against oversized or negative values before we try to
+// read that many bytes.
+const maxChunkSize = 16 << 20 // 16MiB
+
+// chunkedReader decodes a body sent with "Transfer-Encoding: chunked": a
+// sequence of "size\r\n<data>\r\n" frames terminated by a zero-sized chunk
+// and a (possibly empty) trailing header section.
+type chunkedReader struct {
+	br        *bufio.Reader
+	remaining int // bytes left in the chunk currently being read
+	err       error
+}
+
+// newChunkedReader wraps br to decode a chunked body, exposed as
+// Request.Body.
+func newChunkedReader(br *bufio.Reader) io.ReadCloser {
+	return &chunkedReader{br: br}
+}
+
+func (r *chunkedReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	if r.remaining == 0 {
+		if err := r.nextChunk(); err != nil {
+			r.err = err
+			return 0, err
+		}
+		if r.remaining == 0 {
+			r.err = io.EOF
+			return 0, io.EOF
+		}
+	}
+
+	if len(p) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.br.Read(p)
+	r.remaining -= n
+	if err != nil {
+		r.err = err
+		return n, err
+	}
+
+	if r.remaining == 0 {
+		// Consume the CRLF that terminates the chunk data.
+		if _, err := ReadLine(r.br); err != nil {
+			r.err = err
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+// Close marks the reader as exhausted; a chunked body has no underlying
+// resource of its own to release.
+func (r *chunkedReader) Close() error {
+	r.err = io.ErrClosedPipe
+	return nil
+}
+
+// nextChunk reads a chunk-size line and sets r.remaining. A zero size marks
+// the end of the body, in which case nextChunk also consumes the trailing
+// header section up to its terminating blank line.
+func (r *chunkedReader) nextChunk() error {
+	line, err := ReadLine(r.br)
+	if err != nil {
+		return err
+	}
+
+	// Discard chunk extensions ("size;ext=value"); only the size matters here.
+	if i := strings.IndexByte(line, ';'); i >= 0 {
+		line = line[:i]
+	}
+
+	size, err := strconv.ParseUint(strings.TrimSpace(line), 16, 64)
+	if err != nil {
+		return fmt.Errorf("malformed chunk size %q", line)
+	}
+	if size > maxChunkSize {
+		return fmt.Errorf("chunk size %d exceeds %d byte limit", size, maxChunkSize)
+	}
+	r.remaining = int(size)
+
+	if size == 0 {
+		for {
+			trailer, err := ReadLine(r.br)
+			if err != nil {
+				return err
+			}
+			if trailer == "" {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// chunkedWriter wraps w to emit a response body as
+// "Transfer-Encoding: chunked", used when the body's length isn't known
+// up front.
+type chunkedWriter struct {
+	w io.Writer
+}
+
+// newChunkedWriter wraps w to encode writes as chunked frames.
+func newChunkedWriter(w io.Writer) *chunkedWriter {
+	return &chunkedWriter{w: w}
+}
+
+// Write emits p as a single chunk frame. A zero-length p writes nothing,
+// since a zero-sized chunk is reserved for Close to mark the body's end.
+func (w *chunkedWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if _, err := fmt.Fprintf(w.w, "%x\r\n", len(p)); err != nil {
+		return 0, err
+	}
+	if _, err := w.w.Write(p); err != nil {
+		return 0, err
+	}
+	if _, err := io.WriteString(w.w, "\r\n"); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close writes the terminating zero-length chunk and empty trailer section.
+func (w *chunkedWriter) Close() error {
+	_, err := io.WriteString(w.w, "0\r\n\r\n")
+	return err
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestChunkedReader_DecodesMultipleChunks(t *testing.T) {
+	raw := "4\r\nWiki\r\n5\r\npedia\r\n0\r\n\r\n"
+	r := newChunkedReader(bufio.NewReader(strings.NewReader(raw)))
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll returned error: %v", err)
+	}
+	if string(got) != "Wikipedia" {
+		t.Errorf("got body %q, want %q", got, "Wikipedia")
+	}
+}
+
+func TestChunkedReader_ConsumesTrailer(t *testing.T) {
+	raw := "5\r\nhello\r\n0\r\nX-Trailer: done\r\n\r\nnext request unaffected"
+	br := bufio.NewReader(strings.NewReader(raw))
+	r := newChunkedReader(br)
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll returned error: %v", err)
+	}
+	if string(got) != "hello" {
+		t.Errorf("got body %q, want %q", got, "hello")
+	}
+
+	rest, err := io.ReadAll(br)
+	if err != nil {
+		t.Fatalf("failed to read remainder: %v", err)
+	}
+	if string(rest) != "next request unaffected" {
+		t.Errorf("trailer consumed too much or too little, remainder: %q", rest)
+	}
+}
+
+func TestChunkedReader_RejectsOversizedChunk(t *testing.T) {
+	raw := "ffffffff\r\n"
+	r := newChunkedReader(bufio.NewReader(strings.NewReader(raw)))
+
+	if _, err := io.ReadAll(r); err == nil {
+		t.Fatal("expected an error for a chunk size exceeding the limit")
+	}
+}
+
+func TestChunkedWriter_EncodesAndTerminates(t *testing.T) {
+	var sb strings.Builder
+	cw := newChunkedWriter(&sb)
+
+	if _, err := cw.Write([]byte("Wiki")); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	if _, err := cw.Write([]byte("pedia")); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	want := "4\r\nWiki\r\n5\r\npedia\r\n0\r\n\r\n"
+	if got := sb.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestResponseWrite_ChunkedWhenLengthUnknown(t *testing.T) {
+	res := &Response{}
+	res.HandleOK()
+	res.Body = io.NopCloser(strings.NewReader("streamed body"))
+
+	var sb strings.Builder
+	if err := res.Write(&sb); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	got := sb.String()
+	if !strings.Contains(got, "Transfer-Encoding: chunked\r\n") {
+		t.Fatalf("missing Transfer-Encoding header in response:\n%s", got)
+	}
+	if strings.Contains(got, "Content-Length:") {
+		t.Fatalf("chunked response should not carry Content-Length:\n%s", got)
+	}
+	if !strings.HasSuffix(got, "d\r\nstreamed body\r\n0\r\n\r\n") {
+		t.Fatalf("body not chunk-encoded:\n%s", got)
+	}
+}
+
+// FuzzChunkedReaderSize feeds arbitrary chunk-size lines to the decoder to
+// guard against a panic or hang from oversized, negative, or CRLF-injecting
+// chunk-size values.
+func FuzzChunkedReaderSize(f *testing.F) {
+	f.Add("0")
+	f.Add("4")
+	f.Add("ffffffffffffffff")
+	f.Add("-1")
+	f.Add("not-hex")
+	f.Add("4;ext=value")
+
+	f.Fuzz(func(t *testing.T, size string) {
+		raw := size + "\r\n" + strings.Repeat("a", 8) + "\r\n0\r\n\r\n"
+		r := newChunkedReader(bufio.NewReader(strings.NewReader(raw)))
+
+		buf := make([]byte, 4096)
+		for i := 0; i < 1000; i++ {
+			if _, err := r.Read(buf); err != nil {
+				return
+			}
+		}
+		t.Fatal("chunked reader did not terminate within 1000 reads")
+	})
+}
+
+// TestHandleConnection_DrainsUnreadChunkedBodyOnKeepAlive guards against a
+// handler that never reads req.Body leaving undecoded chunk frames on the
+// wire: HandleConnection must drain them itself before parsing the next
+// pipelined request on a keep-alive connection.
+func TestHandleConnection_DrainsUnreadChunkedBodyOnKeepAlive(t *testing.T) {
+	s := &Server{Handler: HandlerFunc(func(res *Response, req *Request) {
+		res.HandleOK() // deliberately never reads req.Body
+	})}
+
+	client, srv := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		s.HandleConnection(srv)
+		close(done)
+	}()
+
+	requests := "GET /a HTTP/1.1\r\nHost: test\r\nTransfer-Encoding: chunked\r\n\r\n" +
+		"4\r\nWiki\r\n0\r\n\r\n" +
+		"GET /b HTTP/1.1\r\nHost: test\r\nConnection: close\r\n\r\n"
+	go func() {
+		_, _ = client.Write([]byte(requests))
+	}()
+
+	res, err := io.ReadAll(client)
+	if err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+	_ = client.Close()
+	<-done
+
+	got := string(res)
+	if n := strings.Count(got, "HTTP/1.1 200 OK"); n != 2 {
+		t.Fatalf("got %d 200 OK responses, want 2; full output:\n%s", n, got)
+	}
+	if strings.Contains(got, "400 Bad Request") {
+		t.Fatalf("second request was corrupted by the undrained chunked body:\n%s", got)
+	}
+}
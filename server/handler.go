@@ -0,0 +1,138 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Handler responds to a single HTTP request by populating res.
+type Handler interface {
+	ServeHTTP(res *Response, req *Request)
+}
+
+// HandlerFunc adapts a plain function to the Handler interface.
+type HandlerFunc func(res *Response, req *Request)
+
+// ServeHTTP calls f(res, req).
+func (f HandlerFunc) ServeHTTP(res *Response, req *Request) {
+	f(res, req)
+}
+
+// ServeMux is an HTTP request multiplexer. It matches each request's URL
+// against the registered patterns and dispatches to the handler registered
+// under the longest matching prefix.
+type ServeMux struct {
+	handlers map[string]Handler
+}
+
+// NewServeMux allocates a ready-to-use ServeMux.
+func NewServeMux() *ServeMux {
+	return &ServeMux{handlers: map[string]Handler{}}
+}
+
+// Handle registers handler to serve requests whose URL begins with pattern.
+func (mux *ServeMux) Handle(pattern string, handler Handler) {
+	mux.handlers[pattern] = handler
+}
+
+// ServeHTTP dispatches to the handler registered under the longest pattern
+// that prefixes req.URL, or answers 404 Not Found if none matches.
+func (mux *ServeMux) ServeHTTP(res *Response, req *Request) {
+	if handler := mux.handler(req.URL); handler != nil {
+		handler.ServeHTTP(res, req)
+		return
+	}
+	res.HandleError(statusNotFound)
+}
+
+func (mux *ServeMux) handler(url string) Handler {
+	var bestPattern string
+	var bestHandler Handler
+	for pattern, handler := range mux.handlers {
+		if !strings.HasPrefix(url, pattern) {
+			continue
+		}
+		if bestHandler == nil || len(pattern) > len(bestPattern) {
+			bestPattern = pattern
+			bestHandler = handler
+		}
+	}
+	return bestHandler
+}
+
+// fileServer is a Handler that serves files rooted at a directory, mapping
+// request-URIs to files with resolveFilePath and its traversal protection.
+type fileServer struct {
+	root string
+}
+
+// FileServer returns a Handler that serves the files beneath docRoot.
+func FileServer(docRoot string) Handler {
+	return &fileServer{root: docRoot}
+}
+
+func (fs *fileServer) ServeHTTP(res *Response, req *Request) {
+	filePath, statusCode := resolveFilePath(fs.root, req.URL)
+	if statusCode != statusOK {
+		res.HandleError(statusCode)
+		return
+	}
+
+	fi, err := os.Stat(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			res.HandleError(statusNotFound)
+		} else {
+			res.HandleError(statusForbidden)
+		}
+		return
+	}
+	if fi.IsDir() {
+		// A directory without a trailing slash has no well-defined file to serve.
+		res.HandleError(statusNotFound)
+		return
+	}
+
+	res.HandleOK()
+	res.FilePath = filePath
+	res.ifModifiedSince = req.Header("If-Modified-Since")
+}
+
+// resolveFilePath maps a request-URI to a file beneath docRoot, appending
+// "index.html" for directory-style URLs and rejecting any path - including
+// one reached only after resolving symlinks - that would escape docRoot.
+func resolveFilePath(docRoot, url string) (path string, statusCode int) {
+	if !strings.HasPrefix(url, "/") {
+		return "", statusBadRequest
+	}
+
+	reqPath := url
+	if strings.HasSuffix(reqPath, "/") {
+		reqPath += "index.html"
+	}
+
+	absRoot, err := filepath.Abs(docRoot)
+	if err != nil {
+		return "", statusForbidden
+	}
+	if resolvedRoot, err := filepath.EvalSymlinks(absRoot); err == nil {
+		absRoot = resolvedRoot
+	}
+
+	cleaned := filepath.Clean(filepath.Join(absRoot, reqPath))
+	if !withinRoot(cleaned, absRoot) {
+		return "", statusForbidden
+	}
+
+	if resolved, err := filepath.EvalSymlinks(cleaned); err == nil && !withinRoot(resolved, absRoot) {
+		return "", statusForbidden
+	}
+
+	return cleaned, statusOK
+}
+
+// withinRoot reports whether path is docRoot itself or a descendant of it.
+func withinRoot(path, docRoot string) bool {
+	return path == docRoot || strings.HasPrefix(path, docRoot+string(filepath.Separator))
+}
@@ -0,0 +1,38 @@
+package server
+
+import "testing"
+
+func TestServeMux_LongestPrefixWins(t *testing.T) {
+	var matched string
+	mux := NewServeMux()
+	mux.Handle("/", HandlerFunc(func(res *Response, req *Request) { matched = "root" }))
+	mux.Handle("/api/", HandlerFunc(func(res *Response, req *Request) { matched = "api" }))
+	mux.Handle("/api/v2/", HandlerFunc(func(res *Response, req *Request) { matched = "api-v2" }))
+
+	mux.ServeHTTP(&Response{}, &Request{URL: "/api/v2/widgets"})
+	if matched != "api-v2" {
+		t.Errorf("got handler %q, want %q", matched, "api-v2")
+	}
+
+	mux.ServeHTTP(&Response{}, &Request{URL: "/api/widgets"})
+	if matched != "api" {
+		t.Errorf("got handler %q, want %q", matched, "api")
+	}
+
+	mux.ServeHTTP(&Response{}, &Request{URL: "/other"})
+	if matched != "root" {
+		t.Errorf("got handler %q, want %q", matched, "root")
+	}
+}
+
+func TestServeMux_NoMatchIsNotFound(t *testing.T) {
+	mux := NewServeMux()
+	mux.Handle("/api/", HandlerFunc(func(res *Response, req *Request) {}))
+
+	res := &Response{}
+	mux.ServeHTTP(res, &Request{URL: "/other"})
+
+	if res.StatusCode != statusNotFound {
+		t.Fatalf("got status %d, want %d", res.StatusCode, statusNotFound)
+	}
+}
@@ -2,45 +2,148 @@ package server
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log"
+	"mime"
 	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const (
 	responseProto = "HTTP/1.1"
 
-	statusOK         		= 200
-	statusMethodNotAllowed  = 405
+	// httpTimeFormat is the wire format used for Date, Last-Modified and
+	// If-Modified-Since header values (RFC 7231 "IMF-fixdate").
+	httpTimeFormat = "Mon, 02 Jan 2006 15:04:05 GMT"
+
+	// defaultMaxHeaderBytes is used when Server.MaxHeaderBytes is zero.
+	defaultMaxHeaderBytes = 1 << 20 // 1MiB
+
+	statusOK                          = 200
+	statusNotModified                 = 304
+	statusBadRequest                  = 400
+	statusForbidden                   = 403
+	statusNotFound                    = 404
+	statusMethodNotAllowed            = 405
+	statusRequestHeaderFieldsTooLarge = 431
+	statusHTTPVersionNotSupported     = 505
 )
 
 var statusText = map[int]string {
-	statusOK:         		"OK",
-	statusMethodNotAllowed: "Method Not Allowed",
+	statusOK:                          "OK",
+	statusNotModified:                 "Not Modified",
+	statusBadRequest:                  "Bad Request",
+	statusForbidden:                   "Forbidden",
+	statusNotFound:                    "Not Found",
+	statusMethodNotAllowed:            "Method Not Allowed",
+	statusRequestHeaderFieldsTooLarge: "Request Header Fields Too Large",
+	statusHTTPVersionNotSupported:     "HTTP Version Not Supported",
 }
 
+// headerOrder fixes the order in which Response.Write emits headers, since
+// map iteration order in Go is randomized.
+var headerOrder = []string{"Date", "Last-Modified", "Content-Type", "Content-Length", "Transfer-Encoding", "Connection"}
+
 type Server struct {
 	// Addr ("host:port") : specifies the TCP address of the server
 	Addr string
 	// DocRoot the root folder under which clients can potentially look up information.
 	// Anything outside this should be "out-of-bounds"
 	DocRoot string
+	// MaxHeaderBytes caps how many bytes of a request's header section
+	// ReadRequest will read before failing with 431. Zero means
+	// defaultMaxHeaderBytes.
+	MaxHeaderBytes int
+	// Hosts maps a Host header value to the Handler that serves requests
+	// for that virtual host, so one listener can serve multiple sites.
+	Hosts map[string]Handler
+	// Handler serves requests whose Host doesn't match an entry in Hosts.
+	// If nil, it falls back to FileServer(DocRoot).
+	Handler Handler
+
+	mu           sync.Mutex
+	listener     net.Listener
+	conns        map[net.Conn]struct{}
+	onShutdown   []func()
+	shuttingDown atomic.Bool
+	wg           sync.WaitGroup
+}
+
+// handlerFor returns the Handler that should serve req.
+func (s *Server) handlerFor(req *Request) Handler {
+	if handler, ok := s.Hosts[req.Host]; ok {
+		return handler
+	}
+	if s.Handler != nil {
+		return s.Handler
+	}
+	return FileServer(s.DocRoot)
 }
 
 type Request struct {
 	Method string // e.g. "GET"
+	URL    string // request-URI, e.g. "/foo/bar.html"
+	Proto  string // e.g. "HTTP/1.1"
+	Host   string // value of the Host header, used to select a virtual host
+
+	// Headers holds parsed header values keyed by lowercased header name,
+	// in the order they were seen, preserving repeated headers.
+	Headers map[string][]string
+
+	// ConnectionClose reports whether the client sent "Connection: close",
+	// asking HandleConnection to close the socket after this response
+	// instead of keeping it open for further requests.
+	ConnectionClose bool
+
+	// Body is the request body. It is non-nil only when the client sent
+	// "Transfer-Encoding: chunked" or a "Content-Length"; handlers that
+	// care about a body must check for nil before reading.
+	Body io.ReadCloser
+}
+
+// Header returns the first value of the header named key, matched
+// case-insensitively, or "" if it wasn't sent.
+func (req *Request) Header(key string) string {
+	values := req.Headers[strings.ToLower(key)]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
 }
 
 type Response struct {
-	StatusCode int    // e.g. 200 / 405
-	Proto string	  // HTTP/1.1
-	FilePath string		  // For this application, we will hard-code this to whatever contents are available in "hello-world.txt"
+	StatusCode int               // e.g. 200 / 405
+	Proto      string            // HTTP/1.1
+	FilePath   string            // path to the file to serve as the body, set by handlers like FileServer
+	Headers    map[string]string // response headers, keyed by canonical header name
+	Body       io.Reader         // response body, set via SetBody; takes precedence over FilePath
+
+	// ifModifiedSince carries the request's If-Modified-Since value through
+	// to Write, which decides whether to answer with 304 Not Modified.
+	ifModifiedSince string
+	// connectionClose carries the request's Connection: close through to
+	// Write, which echoes it back instead of "keep-alive".
+	connectionClose bool
+}
+
+// SetBody sets res's response body to data read from r instead of FilePath,
+// also recording Content-Length up front when r exposes its length (as
+// *bytes.Reader, *bytes.Buffer and *strings.Reader do).
+func (res *Response) SetBody(r io.Reader) {
+	res.Body = r
+	res.FilePath = ""
+	if lr, ok := r.(interface{ Len() int }); ok {
+		res.setHeader("Content-Length", fmt.Sprintf("%d", lr.Len()))
+	}
 }
 
 func (s *Server) ListenAndServe() error {
@@ -57,6 +160,10 @@ func (s *Server) ListenAndServe() error {
 	}
 	fmt.Println("Listening on", ln.Addr())
 
+	s.mu.Lock()
+	s.listener = ln
+	s.mu.Unlock()
+
 	// making sure the listener is closed when we exit
 	defer func() {
 		err = ln.Close()
@@ -65,10 +172,13 @@ func (s *Server) ListenAndServe() error {
 		}
 	}()
 
-	// accept connections forever
+	// accept connections until the listener is closed by Shutdown or Close
 	for {
 		conn, err := ln.Accept()
 		if err != nil {
+			if s.shuttingDown.Load() {
+				return nil
+			}
 			continue
 		}
 		fmt.Println("accepted connection", conn.RemoteAddr())
@@ -76,6 +186,89 @@ func (s *Server) ListenAndServe() error {
 	}
 }
 
+// trackConn adds or removes conn from the set of in-flight connections that
+// Shutdown waits to drain and Close force-closes.
+func (s *Server) trackConn(conn net.Conn, add bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if add {
+		if s.conns == nil {
+			s.conns = map[net.Conn]struct{}{}
+		}
+		s.conns[conn] = struct{}{}
+		return
+	}
+	delete(s.conns, conn)
+}
+
+// RegisterOnShutdown registers f to be called, in its own goroutine, when
+// Shutdown is invoked, so callers can release resources (e.g. stop a
+// background worker) alongside the HTTP server.
+func (s *Server) RegisterOnShutdown(f func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onShutdown = append(s.onShutdown, f)
+}
+
+// Shutdown stops the server from accepting new connections, runs any
+// RegisterOnShutdown callbacks, and waits for in-flight connections to
+// finish their current request before returning. If ctx is done first, it
+// force-closes the remaining connections and returns ctx.Err().
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.shuttingDown.Store(true)
+
+	s.mu.Lock()
+	ln := s.listener
+	onShutdown := s.onShutdown
+	s.mu.Unlock()
+
+	if ln != nil {
+		_ = ln.Close()
+	}
+	for _, f := range onShutdown {
+		go f()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.closeActiveConns()
+		return ctx.Err()
+	}
+}
+
+// Close stops the server immediately: the listener and every in-flight
+// connection are closed without waiting for requests in progress to finish.
+func (s *Server) Close() error {
+	s.shuttingDown.Store(true)
+
+	s.mu.Lock()
+	ln := s.listener
+	s.mu.Unlock()
+
+	var err error
+	if ln != nil {
+		err = ln.Close()
+	}
+	s.closeActiveConns()
+	return err
+}
+
+func (s *Server) closeActiveConns() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+}
+
 func (s *Server) ValidateServerSetup() error {
 	// Validating the doc root of the server
 	fi, err := os.Stat(s.DocRoot)
@@ -93,6 +286,11 @@ func (s *Server) ValidateServerSetup() error {
 
 // HandleConnection reads requests from the accepted conn and handles them.
 func (s *Server) HandleConnection(conn net.Conn) {
+	s.wg.Add(1)
+	defer s.wg.Done()
+	s.trackConn(conn, true)
+	defer s.trackConn(conn, false)
+
 	br := bufio.NewReader(conn)
 	for {
 		// Set timeout
@@ -103,7 +301,7 @@ func (s *Server) HandleConnection(conn net.Conn) {
 		}
 
 		// Read next request from the client
-		req, err := ReadRequest(br)
+		req, err := ReadRequest(br, s.MaxHeaderBytes)
 
 		// Handle EOF
 		if errors.Is(err, io.EOF) {
@@ -120,11 +318,15 @@ func (s *Server) HandleConnection(conn net.Conn) {
 			return
 		}
 
-		// Handle the request which is not a GET and immediately close the connection and return
+		// Handle the request which could not be parsed or served and immediately close the connection and return
 		if err != nil {
 			log.Printf("Handle bad request for error: %v", err)
+			statusCode := statusBadRequest
+			if re, ok := err.(*requestError); ok {
+				statusCode = re.statusCode
+			}
 			res := &Response{}
-			res.HandleBadRequest()
+			res.HandleError(statusCode)
 			_ = res.Write(conn)
 			_ = conn.Close()
 			return
@@ -132,25 +334,37 @@ func (s *Server) HandleConnection(conn net.Conn) {
 
 		// Handle good request
 		log.Printf("Handle good request: %v", req)
-		res := s.HandleGoodRequest()
+		closing := req.ConnectionClose || s.shuttingDown.Load()
+		res := &Response{connectionClose: closing}
+		s.handlerFor(req).ServeHTTP(res, req)
 		err = res.Write(conn)
 		if err != nil {
 			fmt.Println(err)
 		}
 
+		// Drain any body (chunked or Content-Length) the handler didn't
+		// fully read, so the next ReadRequest on this connection starts at
+		// the next request line instead of mid-body.
+		if req.Body != nil {
+			if _, err := io.Copy(io.Discard, req.Body); err != nil {
+				log.Printf("Failed to drain request body for %v: %v", conn.RemoteAddr(), err)
+				_ = conn.Close()
+				return
+			}
+		}
+
+		// Shutdown may have begun while this request was being served.
+		if closing || s.shuttingDown.Load() {
+			log.Printf("Closing connection to %v", conn.RemoteAddr())
+			_ = conn.Close()
+			return
+		}
+
 		// We'll never close the connection and handle as many requests for this connection and pass on this
 		// responsibility to the timeout mechanism
 	}
 }
 
-func (s *Server) HandleGoodRequest() (res *Response) {
-	res = &Response{}
-	res.HandleOK()
-	res.FilePath = filepath.Join(s.DocRoot, "hello-world.txt")
-
-	return res
-}
-
 // HandleOK prepares res to be a 200 OK response
 // ready to be written back to client.
 func (res *Response) HandleOK() {
@@ -158,10 +372,11 @@ func (res *Response) HandleOK() {
 	res.StatusCode = statusOK
 }
 
-// HandleBadRequest prepares res to be a 405 Method Not allowed response
-func (res *Response) HandleBadRequest() {
+// HandleError prepares res to be an error response carrying statusCode and
+// no body.
+func (res *Response) HandleError(statusCode int) {
 	res.init()
-	res.StatusCode = statusMethodNotAllowed
+	res.StatusCode = statusCode
 	res.FilePath = ""
 }
 
@@ -169,26 +384,47 @@ func (res *Response) init() {
 	res.Proto = responseProto
 }
 
-func ReadRequest(br *bufio.Reader) (req *Request, err error) {
-	req = &Request{}
+// ReadRequest reads a single request from br. maxHeaderBytes bounds the
+// total size of the request line and header section - including the start
+// line - that ReadRequest will read before giving up with a 431; zero or
+// negative means defaultMaxHeaderBytes. Each line is capped as it's read
+// rather than only after being fully buffered, so a line with no CRLF
+// can't force unbounded allocation before the limit is enforced.
+func ReadRequest(br *bufio.Reader, maxHeaderBytes int) (req *Request, err error) {
+	if maxHeaderBytes <= 0 {
+		maxHeaderBytes = defaultMaxHeaderBytes
+	}
+	remaining := maxHeaderBytes
+
+	req = &Request{Headers: map[string][]string{}}
 
 	// Read start line
-	line, err := ReadLine(br)
+	line, err := readBoundedLine(br, &remaining)
+	if errors.Is(err, errLineTooLong) {
+		return nil, newRequestError(statusRequestHeaderFieldsTooLarge, "start line too large", "")
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	req.Method, err = parseRequestLine(line)
+	req.Method, req.URL, req.Proto, err = parseRequestLine(line)
 	if err != nil {
-		return nil, badStringError("malformed start line", line)
+		return nil, newRequestError(statusBadRequest, "malformed start line", line)
 	}
 
 	if !validMethod(req.Method) {
-		return nil, badStringError("invalid method", req.Method)
+		return nil, newRequestError(statusMethodNotAllowed, "invalid method", req.Method)
+	}
+
+	if req.Proto != responseProto {
+		return nil, newRequestError(statusHTTPVersionNotSupported, "unsupported proto", req.Proto)
 	}
 
 	for {
-		line, err := ReadLine(br)
+		line, err := readBoundedLine(br, &remaining)
+		if errors.Is(err, errLineTooLong) {
+			return nil, newRequestError(statusRequestHeaderFieldsTooLarge, "header section too large", req.URL)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -196,39 +432,229 @@ func ReadRequest(br *bufio.Reader) (req *Request, err error) {
 			// This marks header end
 			break
 		}
-		fmt.Println("Read line from request", line)
+
+		if line[0] == ' ' || line[0] == '\t' {
+			// RFC 7230 obsoletes header folding; reject it outright.
+			return nil, newRequestError(statusBadRequest, "folded header line", line)
+		}
+
+		key, value, err := parseHeaderLine(line)
+		if err != nil {
+			return nil, newRequestError(statusBadRequest, "malformed header line", line)
+		}
+		req.Headers[key] = append(req.Headers[key], value)
+	}
+
+	req.Host = req.Header("Host")
+	if req.Proto == responseProto && req.Host == "" {
+		return nil, newRequestError(statusBadRequest, "missing required header", "Host")
+	}
+
+	req.ConnectionClose = strings.EqualFold(req.Header("Connection"), "close")
+
+	switch {
+	case strings.EqualFold(req.Header("Transfer-Encoding"), "chunked"):
+		req.Body = newChunkedReader(br)
+	case req.Header("Content-Length") != "":
+		n, err := strconv.ParseInt(req.Header("Content-Length"), 10, 64)
+		if err != nil || n < 0 {
+			return nil, newRequestError(statusBadRequest, "malformed Content-Length", req.Header("Content-Length"))
+		}
+		req.Body = io.NopCloser(io.LimitReader(br, n))
 	}
 
 	return req, nil
 }
 
-// parseRequestLine parses "GET /foo HTTP/1.1" into its individual parts.
-func parseRequestLine(line string) (string, error) {
-	fields := strings.SplitN(line, " ", 2)
-	if len(fields) != 2 {
-		return "", fmt.Errorf("could not parse the request line, got fields %v", fields)
+// readBoundedLine reads one line via ReadLineLimited, capped to whatever of
+// *remaining is left, and deducts the bytes consumed - including the CRLF -
+// from it.
+func readBoundedLine(br *bufio.Reader, remaining *int) (string, error) {
+	line, err := ReadLineLimited(br, *remaining)
+	if err != nil {
+		return line, err
 	}
-	return fields[0], nil
+	*remaining -= len(line) + len("\r\n")
+	return line, nil
+}
+
+// parseHeaderLine splits a "Key: value" header line on the first colon,
+// trims optional whitespace (OWS) around the value, and lowercases the key
+// so lookups are case-insensitive.
+func parseHeaderLine(line string) (key, value string, err error) {
+	i := strings.IndexByte(line, ':')
+	if i < 0 {
+		return "", "", fmt.Errorf("missing colon in header line %q", line)
+	}
+	key = strings.ToLower(strings.TrimSpace(line[:i]))
+	if key == "" {
+		return "", "", fmt.Errorf("empty header name in line %q", line)
+	}
+	return key, strings.TrimSpace(line[i+1:]), nil
+}
+
+// parseRequestLine parses "GET /foo HTTP/1.1" into its method, request-URI
+// and protocol version.
+func parseRequestLine(line string) (method, url, proto string, err error) {
+	fields := strings.SplitN(line, " ", 3)
+	if len(fields) != 3 {
+		return "", "", "", fmt.Errorf("could not parse the request line, got fields %v", fields)
+	}
+	return fields[0], fields[1], fields[2], nil
 }
 
 func validMethod(method string) bool {
 	return method == "GET"
 }
 
-func badStringError(what, val string) error {
-	return fmt.Errorf("%s %q", what, val)
+// requestError is returned by ReadRequest when a request cannot be read or
+// served; statusCode is the HTTP status HandleConnection reports back.
+type requestError struct {
+	statusCode int
+	msg        string
 }
 
+func (e *requestError) Error() string { return e.msg }
+
+func newRequestError(statusCode int, what, val string) error {
+	return &requestError{statusCode: statusCode, msg: fmt.Sprintf("%s %q", what, val)}
+}
+
+// Write serializes res as an HTTP/1.1 response onto w: the status line,
+// headers, a blank line, and finally the body - read from res.Body if set,
+// else from res.FilePath. A request for res.FilePath carrying a
+// still-fresh If-Modified-Since is answered with 304 Not Modified and no
+// body.
 func (res *Response) Write(w io.Writer) error {
 	bw := bufio.NewWriter(w)
 
+	if res.Body == nil && res.FilePath != "" && res.StatusCode == statusOK {
+		fi, err := os.Stat(res.FilePath)
+		if err != nil {
+			return err
+		}
+		modTime := fi.ModTime().UTC()
+
+		if notModifiedSince(res.ifModifiedSince, modTime) {
+			res.StatusCode = statusNotModified
+		}
+
+		res.setHeader("Last-Modified", modTime.Format(httpTimeFormat))
+		if res.StatusCode == statusOK {
+			res.setHeader("Content-Type", contentType(res.FilePath))
+			res.setHeader("Content-Length", fmt.Sprintf("%d", fi.Size()))
+		}
+	}
+	res.setHeader("Date", time.Now().UTC().Format(httpTimeFormat))
+	if res.connectionClose {
+		res.setHeader("Connection", "close")
+	} else {
+		res.setHeader("Connection", "keep-alive")
+	}
+
+	// A body whose length SetBody couldn't determine up front (e.g. a
+	// streamed io.Reader) is sent chunked instead of with Content-Length.
+	chunked := res.StatusCode == statusOK && res.Body != nil && res.Headers["Content-Length"] == ""
+	if chunked {
+		res.setHeader("Transfer-Encoding", "chunked")
+	}
+
 	statusLine := fmt.Sprintf("%v %v %v\r\n", res.Proto, res.StatusCode, statusText[res.StatusCode])
 	if _, err := bw.WriteString(statusLine); err != nil {
 		return err
 	}
 
-	if err := bw.Flush(); err != nil {
+	for _, key := range headerOrder {
+		value, ok := res.Headers[key]
+		if !ok {
+			continue
+		}
+		if _, err := fmt.Fprintf(bw, "%s: %s\r\n", key, value); err != nil {
+			return err
+		}
+	}
+
+	if _, err := bw.WriteString("\r\n"); err != nil {
 		return err
 	}
-	return nil
-}
\ No newline at end of file
+
+	if res.StatusCode == statusOK {
+		switch {
+		case chunked:
+			cw := newChunkedWriter(bw)
+			if _, err := io.Copy(cw, res.Body); err != nil {
+				return err
+			}
+			if err := cw.Close(); err != nil {
+				return err
+			}
+		case res.Body != nil:
+			if _, err := io.Copy(bw, res.Body); err != nil {
+				return err
+			}
+		case res.FilePath != "":
+			f, err := os.Open(res.FilePath)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			if _, err := io.Copy(bw, f); err != nil {
+				return err
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// setHeader records a response header, initializing the map on first use.
+func (res *Response) setHeader(key, value string) {
+	if res.Headers == nil {
+		res.Headers = map[string]string{}
+	}
+	res.Headers[key] = value
+}
+
+// notModifiedSince reports whether modTime, truncated to whole seconds as
+// the wire format carries no finer resolution, is no later than the
+// If-Modified-Since value supplied by the client.
+func notModifiedSince(ifModifiedSince string, modTime time.Time) bool {
+	if ifModifiedSince == "" {
+		return false
+	}
+	t, err := time.Parse(httpTimeFormat, ifModifiedSince)
+	if err != nil {
+		return false
+	}
+	return !modTime.Truncate(time.Second).After(t)
+}
+
+// contentType infers a MIME type for path from its extension, falling back
+// to a small content sniff when the extension is unknown.
+func contentType(path string) string {
+	if ext := filepath.Ext(path); ext != "" {
+		if ct := mime.TypeByExtension(ext); ct != "" {
+			return ct
+		}
+	}
+	return sniffContentType(path)
+}
+
+// sniffContentType peeks at the start of the file at path to distinguish
+// text from binary content when the extension doesn't tell us.
+func sniffContentType(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	defer f.Close()
+
+	buf := make([]byte, 512)
+	n, _ := f.Read(buf)
+	for _, b := range buf[:n] {
+		if b == 0 {
+			return "application/octet-stream"
+		}
+	}
+	return "text/plain; charset=utf-8"
+}
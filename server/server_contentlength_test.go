@@ -0,0 +1,97 @@
+package server
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestReadRequest_ContentLengthBody(t *testing.T) {
+	request := "GET /index.html HTTP/1.1\r\n" +
+		"Host: test\r\n" +
+		"Content-Length: 5\r\n" +
+		"\r\n" +
+		"hello" +
+		"next request unaffected"
+
+	br := bufio.NewReader(strings.NewReader(request))
+	req, err := ReadRequest(br, 0)
+	if err != nil {
+		t.Fatalf("ReadRequest returned error: %v", err)
+	}
+	if req.Body == nil {
+		t.Fatal("expected a non-nil Body for a Content-Length request")
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	if string(body) != "hello" {
+		t.Errorf("got body %q, want %q", body, "hello")
+	}
+
+	rest, err := io.ReadAll(br)
+	if err != nil {
+		t.Fatalf("failed to read remainder: %v", err)
+	}
+	if string(rest) != "next request unaffected" {
+		t.Errorf("Content-Length body read too much or too little, remainder: %q", rest)
+	}
+}
+
+func TestReadRequest_MalformedContentLength(t *testing.T) {
+	request := "GET /index.html HTTP/1.1\r\n" +
+		"Host: test\r\n" +
+		"Content-Length: not-a-number\r\n" +
+		"\r\n"
+
+	_, err := ReadRequest(bufio.NewReader(strings.NewReader(request)), 0)
+	if err == nil {
+		t.Fatal("expected an error for a malformed Content-Length")
+	}
+	re, ok := err.(*requestError)
+	if !ok || re.statusCode != statusBadRequest {
+		t.Fatalf("got error %v, want a requestError with status %d", err, statusBadRequest)
+	}
+}
+
+// TestHandleConnection_DrainsUnreadContentLengthBodyOnKeepAlive guards
+// against a handler that never reads req.Body leaving body bytes on the
+// wire: HandleConnection must drain them itself before parsing the next
+// pipelined request on a keep-alive connection.
+func TestHandleConnection_DrainsUnreadContentLengthBodyOnKeepAlive(t *testing.T) {
+	s := &Server{Handler: HandlerFunc(func(res *Response, req *Request) {
+		res.HandleOK() // deliberately never reads req.Body
+	})}
+
+	client, srv := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		s.HandleConnection(srv)
+		close(done)
+	}()
+
+	requests := "GET /a HTTP/1.1\r\nHost: test\r\nContent-Length: 5\r\n\r\nhello" +
+		"GET /b HTTP/1.1\r\nHost: test\r\nConnection: close\r\n\r\n"
+	go func() {
+		_, _ = client.Write([]byte(requests))
+	}()
+
+	res, err := io.ReadAll(client)
+	if err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+	_ = client.Close()
+	<-done
+
+	got := string(res)
+	if n := strings.Count(got, "HTTP/1.1 200 OK"); n != 2 {
+		t.Fatalf("got %d 200 OK responses, want 2; full output:\n%s", n, got)
+	}
+	if strings.Contains(got, "400 Bad Request") {
+		t.Fatalf("second request was corrupted by the undrained Content-Length body:\n%s", got)
+	}
+}
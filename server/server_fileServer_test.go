@@ -0,0 +1,77 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileServer_ServesFile(t *testing.T) {
+	docRoot := t.TempDir()
+	if err := os.WriteFile(filepath.Join(docRoot, "page.html"), []byte("hi"), 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	res := &Response{}
+	FileServer(docRoot).ServeHTTP(res, &Request{URL: "/page.html"})
+
+	if res.StatusCode != statusOK {
+		t.Fatalf("got status %d, want %d", res.StatusCode, statusOK)
+	}
+	if res.FilePath != filepath.Join(docRoot, "page.html") {
+		t.Errorf("got FilePath %q, want the fixture under docRoot", res.FilePath)
+	}
+}
+
+func TestFileServer_DirectoryServesIndex(t *testing.T) {
+	docRoot := t.TempDir()
+	if err := os.WriteFile(filepath.Join(docRoot, "index.html"), []byte("home"), 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	res := &Response{}
+	FileServer(docRoot).ServeHTTP(res, &Request{URL: "/"})
+
+	if res.StatusCode != statusOK {
+		t.Fatalf("got status %d, want %d", res.StatusCode, statusOK)
+	}
+	if res.FilePath != filepath.Join(docRoot, "index.html") {
+		t.Errorf("got FilePath %q, want index.html under docRoot", res.FilePath)
+	}
+}
+
+func TestFileServer_MissingFile(t *testing.T) {
+	res := &Response{}
+	FileServer(t.TempDir()).ServeHTTP(res, &Request{URL: "/nope.html"})
+
+	if res.StatusCode != statusNotFound {
+		t.Fatalf("got status %d, want %d", res.StatusCode, statusNotFound)
+	}
+}
+
+func TestFileServer_PathTraversalForbidden(t *testing.T) {
+	res := &Response{}
+	FileServer(t.TempDir()).ServeHTTP(res, &Request{URL: "/../etc/passwd"})
+
+	if res.StatusCode != statusForbidden {
+		t.Fatalf("got status %d, want %d", res.StatusCode, statusForbidden)
+	}
+}
+
+func TestFileServer_SymlinkEscapeForbidden(t *testing.T) {
+	docRoot := t.TempDir()
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("shh"), 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(docRoot, "link.txt")); err != nil {
+		t.Skipf("symlinks not supported in this environment: %v", err)
+	}
+
+	res := &Response{}
+	FileServer(docRoot).ServeHTTP(res, &Request{URL: "/link.txt"})
+
+	if res.StatusCode != statusForbidden {
+		t.Fatalf("got status %d, want %d", res.StatusCode, statusForbidden)
+	}
+}
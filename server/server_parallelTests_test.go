@@ -40,7 +40,7 @@ func TestReadBadRequest_parallel(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			reqGot, err := ReadRequest(bufio.NewReader(strings.NewReader(tt.req)))
+			reqGot, err := ReadRequest(bufio.NewReader(strings.NewReader(tt.req)), 0)
 			checkBadRequest_slow(t, err, reqGot)
 		})
 	}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTempFile(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+	return path
+}
+
+func TestResponseWrite_OK(t *testing.T) {
+	path := writeTempFile(t, "hello.txt", "hello, world")
+
+	res := &Response{}
+	res.HandleOK()
+	res.FilePath = path
+
+	var sb strings.Builder
+	if err := res.Write(&sb); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	got := sb.String()
+	if !strings.HasPrefix(got, "HTTP/1.1 200 OK\r\n") {
+		t.Fatalf("missing status line, got: %q", got)
+	}
+	for _, header := range []string{"Content-Type:", "Content-Length: 12", "Date:", "Connection:"} {
+		if !strings.Contains(got, header) {
+			t.Errorf("missing header %q in response:\n%s", header, got)
+		}
+	}
+	if !strings.HasSuffix(got, "hello, world") {
+		t.Errorf("missing body in response:\n%s", got)
+	}
+}
+
+func TestResponseWrite_NotModified(t *testing.T) {
+	path := writeTempFile(t, "hello.txt", "hello, world")
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat temp file: %v", err)
+	}
+
+	res := &Response{}
+	res.HandleOK()
+	res.FilePath = path
+	res.ifModifiedSince = fi.ModTime().UTC().Format(httpTimeFormat)
+
+	var sb strings.Builder
+	if err := res.Write(&sb); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	got := sb.String()
+	if !strings.HasPrefix(got, "HTTP/1.1 304 Not Modified\r\n") {
+		t.Fatalf("expected 304 status line, got: %q", got)
+	}
+	if strings.Contains(got, "hello, world") {
+		t.Errorf("304 response should not carry a body:\n%s", got)
+	}
+}
+
+func TestReadRequest_HeaderSectionTooLarge(t *testing.T) {
+	request := "GET /index.html HTTP/1.1\r\n" +
+		"Host: test\r\n" +
+		"X-Padding: " + strings.Repeat("a", 100) + "\r\n" +
+		"\r\n"
+
+	_, err := ReadRequest(bufio.NewReader(strings.NewReader(request)), 32)
+	if err == nil {
+		t.Fatal("expected an error for a header section exceeding maxHeaderBytes")
+	}
+	re, ok := err.(*requestError)
+	if !ok || re.statusCode != statusRequestHeaderFieldsTooLarge {
+		t.Fatalf("got error %v, want a requestError with status %d", err, statusRequestHeaderFieldsTooLarge)
+	}
+}
+
+func TestReadRequest_StartLineTooLarge(t *testing.T) {
+	// No CRLF anywhere in the input; ReadRequest must reject this with 431
+	// as soon as the limit is crossed rather than buffering the whole
+	// (unterminated) line first.
+	request := strings.Repeat("a", 100)
+
+	_, err := ReadRequest(bufio.NewReader(strings.NewReader(request)), 32)
+	if err == nil {
+		t.Fatal("expected an error for a start line exceeding maxHeaderBytes")
+	}
+	re, ok := err.(*requestError)
+	if !ok || re.statusCode != statusRequestHeaderFieldsTooLarge {
+		t.Fatalf("got error %v, want a requestError with status %d", err, statusRequestHeaderFieldsTooLarge)
+	}
+}
+
+func TestParseIfModifiedSinceHeader(t *testing.T) {
+	now := time.Now().UTC().Format(httpTimeFormat)
+	req, err := ReadRequest(bufio.NewReader(strings.NewReader(
+		"GET /index.html HTTP/1.1\r\n" +
+			"Host: test\r\n" +
+			"If-Modified-Since: " + now + "\r\n" +
+			"\r\n")), 0)
+	if err != nil {
+		t.Fatalf("ReadRequest returned error: %v", err)
+	}
+	if got := req.Header("If-Modified-Since"); got != now {
+		t.Errorf("got If-Modified-Since %q, want %q", got, now)
+	}
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// blockingHandler holds a request open until release is closed, so the test
+// can shut the server down while a request is still in flight.
+func blockingHandler(entered chan<- struct{}, release <-chan struct{}) Handler {
+	return HandlerFunc(func(res *Response, req *Request) {
+		entered <- struct{}{}
+		<-release
+		res.HandleOK()
+	})
+}
+
+func TestServer_ShutdownWaitsForInFlightConnections(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	s := &Server{Handler: blockingHandler(entered, release)}
+
+	client, srv := net.Pipe()
+	go s.HandleConnection(srv)
+
+	go func() {
+		_, _ = client.Write([]byte("GET /slow HTTP/1.1\r\nHost: test\r\nConnection: close\r\n\r\n"))
+	}()
+	<-entered
+
+	// Drain the response so res.Write(srv) can complete once release is
+	// closed below; otherwise it blocks forever on the unbuffered pipe and
+	// Shutdown never sees the connection finish.
+	go func() { _, _ = io.Copy(io.Discard, client) }()
+
+	shutdownDone := make(chan error, 1)
+	go func() {
+		shutdownDone <- s.Shutdown(context.Background())
+	}()
+
+	select {
+	case <-shutdownDone:
+		t.Fatal("Shutdown returned before the in-flight request finished")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-shutdownDone; err != nil {
+		t.Fatalf("Shutdown returned error: %v", err)
+	}
+	_ = client.Close()
+}
+
+func TestServer_ShutdownForceClosesAfterDeadline(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	s := &Server{Handler: blockingHandler(entered, release)}
+
+	client, srv := net.Pipe()
+	defer client.Close()
+	go s.HandleConnection(srv)
+
+	go func() {
+		_, _ = client.Write([]byte("GET /slow HTTP/1.1\r\nHost: test\r\nConnection: close\r\n\r\n"))
+	}()
+	<-entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); err == nil {
+		t.Fatal("expected Shutdown to return the context's deadline error")
+	}
+}
+
+func TestServer_RegisterOnShutdownIsCalled(t *testing.T) {
+	s := &Server{}
+
+	called := make(chan struct{})
+	s.RegisterOnShutdown(func() { close(called) })
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown returned error: %v", err)
+	}
+
+	select {
+	case <-called:
+	case <-time.After(time.Second):
+		t.Fatal("RegisterOnShutdown callback was not called")
+	}
+}
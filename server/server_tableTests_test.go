@@ -34,15 +34,55 @@ func TestReadGoodRequest(t *testing.T) {
 			"GET /index.html HTTP/1.1\r\n" +
 				"Host: test\r\n" +
 				"\r\n",
+			&Request{
+				Method:  "GET",
+				URL:     "/index.html",
+				Proto:   "HTTP/1.1",
+				Host:    "test",
+				Headers: map[string][]string{"host": {"test"}},
+			},
+		},
+		{
+			"Multi-value headers",
+			"GET /index.html HTTP/1.1\r\n" +
+				"Host: test\r\n" +
+				"X-Custom: one\r\n" +
+				"X-Custom: two\r\n" +
+				"\r\n",
 			&Request{
 				Method: "GET",
+				URL:    "/index.html",
+				Proto:  "HTTP/1.1",
+				Host:   "test",
+				Headers: map[string][]string{
+					"host":     {"test"},
+					"x-custom": {"one", "two"},
+				},
+			},
+		},
+		{
+			"Connection close",
+			"GET /index.html HTTP/1.1\r\n" +
+				"Host: test\r\n" +
+				"Connection: close\r\n" +
+				"\r\n",
+			&Request{
+				Method: "GET",
+				URL:    "/index.html",
+				Proto:  "HTTP/1.1",
+				Host:   "test",
+				Headers: map[string][]string{
+					"host":       {"test"},
+					"connection": {"close"},
+				},
+				ConnectionClose: true,
 			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			reqGot, err := ReadRequest(bufio.NewReader(strings.NewReader(tt.reqText)))
+			reqGot, err := ReadRequest(bufio.NewReader(strings.NewReader(tt.reqText)), 0)
 			checkGoodRequest(t, err, reqGot, tt.reqWant)
 		})
 	}
@@ -69,11 +109,19 @@ func TestReadBadRequest(t *testing.T) {
 			"NotSupportedHTTPVerb",
 			"POST /index.html HTTP/1.0\r\nHost: test\r\n\r\n",
 		},
+		{
+			"MissingHost",
+			"GET /index.html HTTP/1.1\r\n\r\n",
+		},
+		{
+			"FoldedHeader",
+			"GET /index.html HTTP/1.1\r\nHost: test\r\n Continuation: not allowed\r\n\r\n",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			reqGot, err := ReadRequest(bufio.NewReader(strings.NewReader(tt.req)))
+			reqGot, err := ReadRequest(bufio.NewReader(strings.NewReader(tt.req)), 0)
 			checkBadRequest(t, err, reqGot)
 		})
 	}
@@ -91,10 +139,18 @@ func TestReadMultipleRequests(t *testing.T) {
 				"GET /index.html HTTP/1.1\r\nHost: test\r\n\r\n",
 			[]*Request{
 				{
-					Method: "GET",
+					Method:  "GET",
+					URL:     "/index.html",
+					Proto:   "HTTP/1.1",
+					Host:    "test",
+					Headers: map[string][]string{"host": {"test"}},
 				},
 				{
-					Method: "GET",
+					Method:  "GET",
+					URL:     "/index.html",
+					Proto:   "HTTP/1.1",
+					Host:    "test",
+					Headers: map[string][]string{"host": {"test"}},
 				},
 			},
 		},
@@ -104,7 +160,11 @@ func TestReadMultipleRequests(t *testing.T) {
 				"GETT /index.html HTTP/1.1\r\nHost: test\r\n\r\n",
 			[]*Request{
 				{
-					Method: "GET",
+					Method:  "GET",
+					URL:     "/index.html",
+					Proto:   "HTTP/1.1",
+					Host:    "test",
+					Headers: map[string][]string{"host": {"test"}},
 				},
 				nil,
 			},
@@ -115,7 +175,7 @@ func TestReadMultipleRequests(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			br := bufio.NewReader(strings.NewReader(tt.reqText))
 			for _, reqWant := range tt.reqsWant {
-				reqGot, err := ReadRequest(br)
+				reqGot, err := ReadRequest(br, 0)
 				if reqWant != nil {
 					checkGoodRequest(t, err, reqGot, reqWant)
 				} else {
@@ -11,7 +11,7 @@ func TestHandleConnection_Simple_GET(t *testing.T) {
 			   "Host: test\r\n" +
 			   "\r\n"
 
-	req, err := ReadRequest(bufio.NewReader(strings.NewReader(request)))
+	req, err := ReadRequest(bufio.NewReader(strings.NewReader(request)), 0)
 	if req.Method != "GET" || err != nil {
 		t.Fatalf("incorrect parsing of request %v : %v", req, err)
 	}
@@ -22,7 +22,7 @@ func TestHandleConnection_Simple_POST(t *testing.T) {
 		"Host: test\r\n" +
 		"\r\n"
 
-	req, err := ReadRequest(bufio.NewReader(strings.NewReader(request)))
+	req, err := ReadRequest(bufio.NewReader(strings.NewReader(request)), 0)
 	if req != nil || err == nil {
 		t.Fatalf("POST should not be allowed, looks like it is! %v : %v", req, err)
 	}
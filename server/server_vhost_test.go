@@ -0,0 +1,64 @@
+package server
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// doRequest sends request over a net.Pipe connection served by s.HandleConnection
+// and returns the raw response.
+func doRequest(t *testing.T, s *Server, request string) string {
+	t.Helper()
+
+	client, srv := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		s.HandleConnection(srv)
+		close(done)
+	}()
+
+	if _, err := client.Write([]byte(request)); err != nil {
+		t.Fatalf("failed to write request: %v", err)
+	}
+
+	res, err := io.ReadAll(client)
+	if err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+	_ = client.Close()
+	<-done
+
+	return string(res)
+}
+
+func TestServer_VirtualHosts(t *testing.T) {
+	rootA := t.TempDir()
+	rootB := t.TempDir()
+	if err := os.WriteFile(filepath.Join(rootA, "page.html"), []byte("from a"), 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(rootB, "page.html"), []byte("from b"), 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	s := &Server{
+		Hosts: map[string]Handler{
+			"a.test": FileServer(rootA),
+			"b.test": FileServer(rootB),
+		},
+	}
+
+	respA := doRequest(t, s, "GET /page.html HTTP/1.1\r\nHost: a.test\r\nConnection: close\r\n\r\n")
+	if !strings.HasSuffix(respA, "from a") {
+		t.Errorf("host a.test got %q, want body %q", respA, "from a")
+	}
+
+	respB := doRequest(t, s, "GET /page.html HTTP/1.1\r\nHost: b.test\r\nConnection: close\r\n\r\n")
+	if !strings.HasSuffix(respB, "from b") {
+		t.Errorf("host b.test got %q, want body %q", respB, "from b")
+	}
+}
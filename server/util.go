@@ -2,27 +2,38 @@ package server
 
 import (
 	"bufio"
-	"strings"
+	"errors"
 )
 
+// errLineTooLong is returned by ReadLineLimited when a line exceeds maxLen
+// bytes before its terminating "\r\n" is found.
+var errLineTooLong = errors.New("line exceeds maximum length")
+
 // ReadLine reads a single line ending with "\r\n" from br,
 // striping the "\r\n" line end from the returned string.
 // If any error occurs, data read before the error is also returned.
 // You might find this function useful in parsing requests.
 func ReadLine(br *bufio.Reader) (string, error) {
-	var line string
+	return ReadLineLimited(br, -1)
+}
+
+// ReadLineLimited behaves like ReadLine but fails with errLineTooLong as
+// soon as more than maxLen bytes have been read without finding the
+// terminating "\r\n", instead of only after the whole (possibly unbounded)
+// line has been buffered. A negative maxLen means no limit.
+func ReadLineLimited(br *bufio.Reader, maxLen int) (string, error) {
+	var line []byte
 	for {
-		s, err := br.ReadString('\n')
-		line += s
-		// Return the error
+		b, err := br.ReadByte()
 		if err != nil {
-			return line, err
+			return string(line), err
+		}
+		line = append(line, b)
+		if maxLen >= 0 && len(line) > maxLen {
+			return string(line), errLineTooLong
 		}
-		// Return the line when reaching line end
-		if strings.HasSuffix(line, "\r\n") {
-			// Striping the line end
-			line = line[:len(line)-2]
-			return line, nil
+		if len(line) >= 2 && line[len(line)-2] == '\r' && line[len(line)-1] == '\n' {
+			return string(line[:len(line)-2]), nil
 		}
 	}
 }
@@ -0,0 +1,28 @@
+package server
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestReadLineLimited_FailsOnLongUnterminatedLine(t *testing.T) {
+	// No CRLF anywhere in the input; ReadLineLimited must fail as soon as
+	// the accumulated length crosses maxLen, not only once the whole
+	// (potentially unbounded) line has been buffered.
+	r := bufio.NewReader(strings.NewReader(strings.Repeat("a", 1000)))
+	if _, err := ReadLineLimited(r, 10); err != errLineTooLong {
+		t.Fatalf("got error %v, want errLineTooLong", err)
+	}
+}
+
+func TestReadLineLimited_AllowsLineWithinLimit(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("hello\r\nrest"))
+	got, err := ReadLineLimited(r, 10)
+	if err != nil {
+		t.Fatalf("ReadLineLimited returned error: %v", err)
+	}
+	if got != "hello" {
+		t.Errorf("got %q, want %q", got, "hello")
+	}
+}